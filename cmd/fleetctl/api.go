@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/kolide/fleet/server/service"
 	"github.com/pkg/errors"
@@ -9,6 +12,14 @@ import (
 )
 
 func clientFromCLI(c *cli.Context) (*service.Client, error) {
+	return clientForContext(c, c.String("context"))
+}
+
+// clientForContext builds a *service.Client from the named config
+// context rather than always reading --context, so clientsFromCLI
+// can build one client per context while sharing this logic with the
+// single-context clientFromCLI.
+func clientForContext(c *cli.Context, contextName string) (*service.Client, error) {
 	if err := makeConfigIfNotExists(c.String("config")); err != nil {
 		return nil, errors.Wrapf(err, "error verifying that config exists at %s", c.String("config"))
 	}
@@ -18,9 +29,9 @@ func clientFromCLI(c *cli.Context) (*service.Client, error) {
 		return nil, err
 	}
 
-	cc, ok := config.Contexts[c.String("context")]
+	cc, ok := config.Contexts[contextName]
 	if !ok {
-		return nil, fmt.Errorf("context %q is not found", c.String("context"))
+		return nil, fmt.Errorf("context %q is not found", contextName)
 	}
 
 	if cc.Address == "" {
@@ -45,3 +56,116 @@ func clientFromCLI(c *cli.Context) (*service.Client, error) {
 
 	return fleet, nil
 }
+
+// contextNamesFromCLI resolves the set of config context names a
+// command should fan out to: every context in the config file when
+// --all-contexts is set, otherwise each comma-separated name in
+// --context (a bare name, the common case, is just a slice of one).
+func contextNamesFromCLI(c *cli.Context) ([]string, error) {
+	if c.Bool("all-contexts") {
+		config, err := readConfig(c.String("config"))
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(config.Contexts))
+		for name := range config.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(c.String("context"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// clientsFromCLIMaxConcurrency bounds how many contexts
+// clientsFromCLI (and the commands built on top of it) will dial at
+// once, so --all-contexts against a large fleet of Fleet servers
+// doesn't open them all in a single burst.
+const clientsFromCLIMaxConcurrency = 8
+
+// ContextErrors collects the per-context failures a fanned-out
+// command hit, keyed by context name, so a failure in one context
+// doesn't stop the command from reporting (or having already
+// applied) the rest.
+type ContextErrors map[string]error
+
+func (e ContextErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %s", name, e[name])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// clientsFromCLI resolves --context (accepting a comma-separated
+// list) or --all-contexts into a named *service.Client per Fleet
+// server, dialing them concurrently. This turns fleetctl into a
+// control plane for users running separate Fleet instances per
+// environment or region: `fleetctl get packs --all-contexts` and
+// `fleetctl apply --all-contexts` fan out across every one of them.
+//
+// Building an individual context's client failing does not abort the
+// others; failures are returned as ContextErrors alongside whatever
+// clients did succeed, so callers can run against those and report
+// the rest.
+func clientsFromCLI(c *cli.Context) (map[string]*service.Client, error) {
+	names, err := contextNamesFromCLI(c)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		context string
+		client  *service.Client
+		err     error
+	}
+
+	results := make(chan result, len(names))
+	sem := make(chan struct{}, clientsFromCLIMaxConcurrency)
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			client, err := clientForContext(c, name)
+			results <- result{context: name, client: client, err: err}
+		}(name)
+	}
+	wg.Wait()
+	close(results)
+
+	clients := make(map[string]*service.Client, len(names))
+	var errs ContextErrors
+	for r := range results {
+		if r.err != nil {
+			if errs == nil {
+				errs = ContextErrors{}
+			}
+			errs[r.context] = r.err
+			continue
+		}
+		clients[r.context] = r.client
+	}
+
+	if errs != nil {
+		return clients, errs
+	}
+	return clients, nil
+}
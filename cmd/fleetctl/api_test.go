@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func newContextCLI(t *testing.T, contextFlag string, allContexts bool) *cli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", 0)
+	set.String("context", contextFlag, "")
+	set.Bool("all-contexts", allContexts, "")
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestContextNamesFromCLISingle(t *testing.T) {
+	c := newContextCLI(t, "default", false)
+	names, err := contextNamesFromCLI(c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default"}, names)
+}
+
+func TestContextNamesFromCLICommaSeparated(t *testing.T) {
+	c := newContextCLI(t, " prod , staging ,", false)
+	names, err := contextNamesFromCLI(c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod", "staging"}, names)
+}
+
+func TestContextErrorsError(t *testing.T) {
+	errs := ContextErrors{
+		"staging": errors.New("boom"),
+		"prod":    errors.New("timeout"),
+	}
+	assert.Equal(t, "prod: timeout; staging: boom", errs.Error())
+}
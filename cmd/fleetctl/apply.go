@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/service"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type packSpecs struct {
+	Packs []*kolide.PackSpec `yaml:"packs"`
+}
+
+func applyCommand() cli.Command {
+	var (
+		flFilename    string
+		flDryRun      bool
+		flAllContexts bool
+		flMerge       bool
+		flStrict      bool
+	)
+	return cli.Command{
+		Name:  "apply",
+		Usage: "Apply files to declaratively manage osquery pack configuration",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "f",
+				Destination: &flFilename,
+				Usage:       "A YAML file containing the specs to apply",
+			},
+			cli.BoolFlag{
+				Name:        "dry-run",
+				Destination: &flDryRun,
+				Usage:       "Compute and print the diff against the server without applying it",
+			},
+			cli.BoolFlag{
+				Name:        "all-contexts",
+				Destination: &flAllContexts,
+				Usage:       "Apply against every context in the config file instead of just --context",
+			},
+			cli.BoolFlag{
+				Name:        "merge",
+				Destination: &flMerge,
+				Usage:       "Preserve scheduled queries not mentioned in the incoming spec instead of removing them",
+			},
+			cli.BoolFlag{
+				Name:        "strict",
+				Destination: &flStrict,
+				Usage:       "Reject the whole apply if any scheduled query fails validation, instead of clamping it",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if flFilename == "" {
+				return errors.New("-f must be specified")
+			}
+
+			b, err := ioutil.ReadFile(flFilename)
+			if err != nil {
+				return errors.Wrap(err, "reading spec file")
+			}
+
+			var specs packSpecs
+			if err := yaml.Unmarshal(b, &specs); err != nil {
+				return errors.Wrap(err, "unmarshaling spec file")
+			}
+
+			opts := kolide.ApplyOptions{Strict: flStrict}
+			if flMerge {
+				opts.Mode = kolide.ApplyModeMerge
+			}
+
+			if !flAllContexts {
+				fleet, err := clientFromCLI(c)
+				if err != nil {
+					return err
+				}
+				return applyPacksToClient(fleet, specs.Packs, opts, flDryRun)
+			}
+
+			clients, clientErr := clientsFromCLI(c)
+			results := make(map[string]error, len(clients))
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			sem := make(chan struct{}, clientsFromCLIMaxConcurrency)
+			for name, fleet := range clients {
+				wg.Add(1)
+				go func(name string, fleet *service.Client) {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					err := applyPacksToClient(fleet, specs.Packs, opts, flDryRun)
+					mu.Lock()
+					results[name] = err
+					mu.Unlock()
+				}(name, fleet)
+			}
+			wg.Wait()
+
+			printContextStatus(results)
+
+			if ctxErrs, ok := clientErr.(ContextErrors); ok {
+				printContextStatus(ctxErrs)
+			}
+			if clientErr != nil || anyFailed(results) {
+				return errors.New("apply failed in one or more contexts; see above")
+			}
+			return nil
+		},
+	}
+}
+
+func applyPacksToClient(fleet *service.Client, packs []*kolide.PackSpec, opts kolide.ApplyOptions, dryRun bool) error {
+	// Diff against what's currently stored *before* applying anything.
+	// Diffing afterward would compare the incoming spec against itself
+	// (since the apply already committed) and always show no changes.
+	diff, err := fleet.DiffPacks(packs)
+	if err != nil {
+		return errors.Wrap(err, "computing diff")
+	}
+
+	if dryRun {
+		printPackDiff(diff)
+		return nil
+	}
+
+	if err := fleet.ApplyPacksWithOptions(packs, opts); err != nil {
+		return errors.Wrap(err, "applying packs")
+	}
+
+	printPackDiff(diff)
+	return nil
+}
+
+func printPackDiff(diff *service.SpecDiff) {
+	for _, pack := range diff.Packs {
+		if pack.New {
+			fmt.Printf("+ pack %s (new)\n", pack.PackName)
+			continue
+		}
+		fmt.Printf("pack %s\n", pack.PackName)
+		for _, q := range pack.Queries {
+			fmt.Printf("  %s query %s\n", q.Change, q.QueryName)
+		}
+		for _, t := range pack.Targets {
+			fmt.Printf("  %s label %s\n", t.Change, t.Label)
+		}
+	}
+}
+
+// printContextStatus prints one line per context in a fanned-out
+// command's result set, in name order, so users running against
+// --all-contexts can tell at a glance which environments failed.
+func printContextStatus(results map[string]error) {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			fmt.Printf("%-20s FAILED: %s\n", name, err)
+		} else {
+			fmt.Printf("%-20s OK\n", name)
+		}
+	}
+}
+
+func anyFailed(results map[string]error) bool {
+	for _, err := range results {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
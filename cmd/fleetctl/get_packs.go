@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/service"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// getPacksCommand is meant to be added to the "get" command's
+// Subcommands alongside the other resource types (queries, labels,
+// hosts, ...); it's kept in its own file since those don't live in
+// this tree.
+func getPacksCommand() cli.Command {
+	var flAllContexts bool
+	return cli.Command{
+		Name:  "packs",
+		Usage: "List the packs currently configured",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:        "all-contexts",
+				Destination: &flAllContexts,
+				Usage:       "List packs from every context in the config file instead of just --context",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if !flAllContexts {
+				fleet, err := clientFromCLI(c)
+				if err != nil {
+					return err
+				}
+				specs, err := fleet.GetPacks()
+				if err != nil {
+					return err
+				}
+				printPackSpecs(specs)
+				return nil
+			}
+
+			clients, clientErr := clientsFromCLI(c)
+
+			type contextResult struct {
+				specs []*kolide.PackSpec
+				err   error
+			}
+			results := make(map[string]contextResult, len(clients))
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			sem := make(chan struct{}, clientsFromCLIMaxConcurrency)
+			for name, fleet := range clients {
+				wg.Add(1)
+				go func(name string, fleet *service.Client) {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					specs, err := fleet.GetPacks()
+					mu.Lock()
+					results[name] = contextResult{specs: specs, err: err}
+					mu.Unlock()
+				}(name, fleet)
+			}
+			wg.Wait()
+
+			names := make([]string, 0, len(results))
+			for name := range results {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			statuses := make(map[string]error, len(results))
+			for _, name := range names {
+				r := results[name]
+				statuses[name] = r.err
+				if r.err == nil {
+					fmt.Printf("== %s ==\n", name)
+					printPackSpecs(r.specs)
+				}
+			}
+			printContextStatus(statuses)
+
+			if ctxErrs, ok := clientErr.(ContextErrors); ok {
+				printContextStatus(ctxErrs)
+			}
+			if clientErr != nil || anyFailed(statuses) {
+				return errors.New("get packs failed in one or more contexts; see above")
+			}
+			return nil
+		},
+	}
+}
+
+func printPackSpecs(specs []*kolide.PackSpec) {
+	for _, spec := range specs {
+		fmt.Printf("%s\t(%d queries)\n", spec.Name, len(spec.Queries))
+	}
+}
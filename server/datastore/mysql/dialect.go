@@ -0,0 +1,25 @@
+package mysql
+
+import "github.com/kolide/fleet/server/datastore/sqlcommon"
+
+// dialect is the SQL dialect the query builders in this package that
+// have been ported to sqlcommon go through; the rest still build
+// MySQL syntax directly. It defaults to MySQL, the historical and still
+// default backend, and is switched by SetDialect. Nothing in this
+// tree calls SetDialect yet: wiring it to a --datastore flag is the
+// responsibility of the server's startup code, which isn't part of
+// this package.
+var dialect sqlcommon.Dialect = sqlcommon.MySQL{}
+
+// SetDialect switches dialect for the rest of the process. driver
+// must be "" or "mysql" (both select MySQL, the default) or
+// "postgres"; anything else returns an error and leaves the current
+// dialect in place.
+func SetDialect(driver string) error {
+	d, err := sqlcommon.New(driver)
+	if err != nil {
+		return err
+	}
+	dialect = d
+	return nil
+}
@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/datastore/sqlcommon"
+)
+
+func TestSetDialect(t *testing.T) {
+	defer func() { dialect = sqlcommon.MySQL{} }()
+
+	if err := SetDialect("postgres"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dialect.Name(); got != "postgres" {
+		t.Fatalf("got %q, want postgres", got)
+	}
+
+	if err := SetDialect("nonsense"); err == nil {
+		t.Fatal("expected error for unknown driver")
+	}
+	if got := dialect.Name(); got != "postgres" {
+		t.Fatalf("dialect should be unchanged after a failed SetDialect, got %q", got)
+	}
+
+	if err := SetDialect("mysql"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dialect.Name(); got != "mysql" {
+		t.Fatalf("got %q, want mysql", got)
+	}
+}
@@ -0,0 +1,16 @@
+package mysql
+
+import "github.com/go-kit/kit/log"
+
+// logger receives non-fatal datastore warnings — currently just the
+// clamped-value warnings validateScheduledQuery logs in non-strict
+// mode — through the same structured logging pipeline as the rest of
+// the app, rather than stdout via the stdlib log package. SetLogger
+// lets the server inject its own logger at startup, mirroring
+// SetDialect.
+var logger log.Logger = log.NewNopLogger()
+
+// SetLogger installs l as the logger used for datastore warnings.
+func SetLogger(l log.Logger) {
+	logger = l
+}
@@ -0,0 +1,24 @@
+package mysql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScheduledQueryNonStrictLogsWarningThroughInjectedLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(log.NewLogfmtLogger(&buf))
+	defer SetLogger(log.NewNopLogger())
+
+	badShard := uint(0)
+	q := kolide.ScheduledQuery{QueryName: "q1", Shard: &badShard, Interval: 60}
+
+	require.NoError(t, validateScheduledQuery(&q, false))
+	assert.Contains(t, buf.String(), "query=q1")
+	assert.Contains(t, buf.String(), "clamped_to=1")
+}
@@ -0,0 +1,145 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/go-multierror"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+const (
+	minShard = 1
+	maxShard = 100
+
+	minInterval = 10 * time.Second
+	maxInterval = 24 * time.Hour
+)
+
+var validPlatforms = map[string]bool{
+	"":        true, // empty means "all platforms"
+	"darwin":  true,
+	"linux":   true,
+	"windows": true,
+	"freebsd": true,
+}
+
+// validatePackSpecs checks every scheduled query in specs against
+// the constraints osqueryd actually enforces (or silently misbehaves
+// on if we don't): a Shard outside [1,100], an unknown Platform, an
+// Interval outside [10s,24h], or a Version that doesn't parse as a
+// semver range would otherwise reach agents as-is and quietly
+// degrade scheduling. In opts.Strict mode every offending query is
+// collected into a single multierror so a user fixes them all in one
+// pass; otherwise each is clamped (or dropped, if it can't be
+// clamped) to a safe value and applied with a logged warning.
+func validatePackSpecs(specs []*kolide.PackSpec, opts kolide.ApplyOptions) error {
+	var result *multierror.Error
+	for _, spec := range specs {
+		for i := range spec.Queries {
+			if err := validateScheduledQuery(&spec.Queries[i], opts.Strict); err != nil {
+				result = multierror.Append(result, errors.Wrapf(err, "pack %q query %q", spec.Name, spec.Queries[i].QueryName))
+			}
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// validateScheduledQuery checks a single scheduled query's shard,
+// platform, interval, and version. In strict mode a violation is
+// returned as an error and q is left untouched; otherwise it's
+// clamped to a valid value in place, a warning is logged through
+// logger, and nil is returned so the batch can still be applied.
+func validateScheduledQuery(q *kolide.ScheduledQuery, strict bool) error {
+	var errs []string
+
+	if q.Shard != nil && (*q.Shard == 0 || *q.Shard > maxShard) {
+		msg := fmt.Sprintf("shard must be in [%d,%d], got %d (0 is rejected rather than silently treated as unset)", minShard, maxShard, *q.Shard)
+		if strict {
+			errs = append(errs, msg)
+		} else {
+			clamped := clampShard(*q.Shard)
+			logger.Log("query", q.QueryName, "warning", msg, "clamped_to", clamped)
+			q.Shard = &clamped
+		}
+	}
+
+	for _, platform := range strings.Split(q.Platform, ",") {
+		p := strings.TrimSpace(platform)
+		if p != "" && !validPlatforms[p] {
+			msg := fmt.Sprintf("unknown platform %q", p)
+			if strict {
+				errs = append(errs, msg)
+			} else {
+				logger.Log("query", q.QueryName, "warning", msg, "action", "dropped from platform list")
+				q.Platform = removePlatform(q.Platform, p)
+			}
+		}
+	}
+
+	interval := time.Duration(q.Interval) * time.Second
+	if interval < minInterval || interval > maxInterval {
+		msg := fmt.Sprintf("interval must be between %s and %s, got %s", minInterval, maxInterval, interval)
+		if strict {
+			errs = append(errs, msg)
+		} else {
+			clamped := clampInterval(interval)
+			logger.Log("query", q.QueryName, "warning", msg, "clamped_to", clamped)
+			q.Interval = uint(clamped.Seconds())
+		}
+	}
+
+	if q.Version != "" {
+		if _, err := semver.ParseRange(q.Version); err != nil {
+			msg := fmt.Sprintf("version %q is not a valid semver range: %s", q.Version, err)
+			if strict {
+				errs = append(errs, msg)
+			} else {
+				logger.Log("query", q.QueryName, "warning", msg, "action", "ignored version constraint")
+				q.Version = ""
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
+
+func clampShard(v uint) uint {
+	switch {
+	case v == 0:
+		return minShard
+	case v > maxShard:
+		return maxShard
+	default:
+		return v
+	}
+}
+
+func clampInterval(d time.Duration) time.Duration {
+	switch {
+	case d < minInterval:
+		return minInterval
+	case d > maxInterval:
+		return maxInterval
+	default:
+		return d
+	}
+}
+
+// removePlatform returns platforms with remove stripped out of its
+// comma-separated list.
+func removePlatform(platforms, remove string) string {
+	var kept []string
+	for _, p := range strings.Split(platforms, ",") {
+		if p = strings.TrimSpace(p); p != "" && p != remove {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, ",")
+}
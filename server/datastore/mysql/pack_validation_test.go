@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScheduledQueryStrictRejects(t *testing.T) {
+	badShard := uint(0)
+	q := kolide.ScheduledQuery{QueryName: "q", Shard: &badShard, Platform: "solaris", Interval: 1, Version: "not-a-semver"}
+
+	err := validateScheduledQuery(&q, true)
+	require.Error(t, err)
+
+	// Strict mode must not mutate the query.
+	assert.Equal(t, uint(0), *q.Shard)
+	assert.Equal(t, "solaris", q.Platform)
+	assert.Equal(t, uint(1), q.Interval)
+	assert.Equal(t, "not-a-semver", q.Version)
+}
+
+func TestValidateScheduledQueryNonStrictClamps(t *testing.T) {
+	badShard := uint(0)
+	q := kolide.ScheduledQuery{QueryName: "q", Shard: &badShard, Platform: "darwin,solaris", Interval: 1, Version: "not-a-semver"}
+
+	err := validateScheduledQuery(&q, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(minShard), *q.Shard)
+	assert.Equal(t, "darwin", q.Platform)
+	assert.Equal(t, uint(minInterval.Seconds()), q.Interval)
+	assert.Equal(t, "", q.Version)
+}
+
+func TestValidateScheduledQueryValidPasses(t *testing.T) {
+	shard := uint(50)
+	q := kolide.ScheduledQuery{QueryName: "q", Shard: &shard, Platform: "darwin,linux", Interval: 3600, Version: ">=1.0.0"}
+
+	assert.NoError(t, validateScheduledQuery(&q, true))
+	assert.NoError(t, validateScheduledQuery(&q, false))
+}
+
+func TestValidatePackSpecsAggregatesErrors(t *testing.T) {
+	badShard := uint(0)
+	specs := []*kolide.PackSpec{
+		{
+			Name: "pack1",
+			Queries: []kolide.ScheduledQuery{
+				{QueryName: "bad1", Shard: &badShard, Interval: 60},
+				{QueryName: "bad2", Interval: 1},
+			},
+		},
+	}
+
+	err := validatePackSpecs(specs, kolide.ApplyOptions{Strict: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad1")
+	assert.Contains(t, err.Error(), "bad2")
+
+	assert.NoError(t, validatePackSpecs(specs, kolide.ApplyOptions{Strict: false}))
+}
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,47 +10,65 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (d *Datastore) ApplyPackSpecs(specs []*kolide.PackSpec) (err error) {
-	tx, err := d.db.Beginx()
-	if err != nil {
-		return errors.Wrap(err, "begin ApplyPackSpec transaction")
+// ApplyPackSpecs applies specs, replacing or merging each pack's
+// scheduled queries depending on opts.Mode (see kolide.ApplyMode).
+// Every query is validated first (see validatePackSpecs); in
+// opts.Strict mode a single invalid query fails the whole batch, so
+// query-specific problems are always caught before any writes.
+//
+// If txOpts carries a caller-supplied transaction, the apply runs on
+// it directly instead of opening (and possibly deadlock-retrying) a
+// transaction of its own, so callers can compose ApplyPackSpecs with
+// other mutations atomically.
+func (d *Datastore) ApplyPackSpecs(specs []*kolide.PackSpec, opts kolide.ApplyOptions, txOpts ...kolide.OptionalArg) error {
+	if err := validatePackSpecs(specs, opts); err != nil {
+		return errors.Wrap(err, "validating pack specs")
 	}
 
-	defer func() {
-		if err != nil {
-			rbErr := tx.Rollback()
-			// It seems possible that there might be a case in
-			// which the error we are dealing with here was thrown
-			// by the call to tx.Commit(), and the docs suggest
-			// this call would then result in sql.ErrTxDone.
-			if rbErr != nil && rbErr != sql.ErrTxDone {
-				panic(fmt.Sprintf("got err '%s' rolling back after err '%s'", rbErr, err))
+	apply := func(tx *sqlx.Tx) error {
+		for _, spec := range specs {
+			if err := applyPackSpec(tx, spec, opts); err != nil {
+				return errors.Wrapf(err, "applying pack '%s'", spec.Name)
 			}
 		}
-	}()
+		return nil
+	}
 
-	for _, spec := range specs {
-		err = applyPackSpec(tx, spec)
-		if err != nil {
-			return errors.Wrapf(err, "applying pack '%s'", spec.Name)
-		}
+	if tx, ok := d.getTransaction(txOpts).(*sqlx.Tx); ok {
+		return apply(tx)
 	}
 
-	err = tx.Commit()
-	return errors.Wrap(err, "commit transaction")
+	return d.WithRetryingTx(context.Background(), apply)
+}
+
+// upsertClauseForMode returns the clause appended to the
+// scheduled_queries INSERT so that ApplyModeMerge overwrites an
+// existing (pack_id, query_name) row in place rather than erroring
+// on the unique key. ApplyModeReplace already deleted any
+// conflicting rows above, so it needs no upsert clause.
+//
+// This assumes scheduled_queries has a unique index on
+// (pack_id, query_name); without it, ON DUPLICATE KEY UPDATE/ON
+// CONFLICT has nothing to key off of and merge mode will insert a
+// duplicate row instead of updating in place. No migration for that
+// index ships in this tree.
+func upsertClauseForMode(mode kolide.ApplyMode) string {
+	if mode != kolide.ApplyModeMerge {
+		return ""
+	}
+	return dialect.Upsert(
+		[]string{"pack_id", "query_name"},
+		[]string{"name", "description", "interval", "snapshot", "removed", "shard", "platform", "version"},
+	)
 }
 
-func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
+func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec, opts kolide.ApplyOptions) error {
 	// Insert/update pack
-	query := `
+	query := fmt.Sprintf(`
 		INSERT INTO packs (name, description, platform)
 		VALUES (?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-			name = VALUES(name),
-			description = VALUES(description),
-			platform = VALUES(platform),
-			deleted = false
-	`
+		%s, deleted = false
+	`, dialect.Upsert([]string{"name"}, []string{"name", "description", "platform"}))
 	if _, err := tx.Exec(query, spec.Name, spec.Description, spec.Platform); err != nil {
 		return errors.Wrap(err, "insert/update pack")
 	}
@@ -63,24 +82,32 @@ func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
 		return errors.Wrap(err, "getting pack ID")
 	}
 
-	// Delete existing scheduled queries for pack
-	query = "DELETE FROM scheduled_queries WHERE pack_id = ?"
-	if _, err := tx.Exec(query, packID); err != nil {
-		return errors.Wrap(err, "delete existing scheduled queries")
+	if opts.Mode == kolide.ApplyModeReplace {
+		// Delete existing scheduled queries for pack; the loop below
+		// re-inserts every query named in the incoming spec, so any
+		// query not mentioned is dropped.
+		query = "DELETE FROM scheduled_queries WHERE pack_id = ?"
+		if _, err := tx.Exec(query, packID); err != nil {
+			return errors.Wrap(err, "delete existing scheduled queries")
+		}
 	}
 
-	// Insert new scheduled queries for pack
+	// Insert new scheduled queries for pack. In ApplyModeMerge this is
+	// an upsert keyed on (pack_id, query_name), so queries the
+	// incoming spec doesn't mention are left untouched instead of
+	// being deleted.
 	for _, q := range spec.Queries {
-		query = `
+		query = fmt.Sprintf(`
 			INSERT INTO scheduled_queries (
-				pack_id, query_name, name, description, ` + "`interval`" + `,
+				pack_id, query_name, name, description, %s,
 				snapshot, removed, shard, platform, version
 			)
 			VALUES (
 				?, ?, ?, ?, ?,
 				?, ?, ?, ?, ?
 			)
-		`
+			%s
+		`, dialect.Quote("interval"), upsertClauseForMode(opts.Mode))
 		_, err := tx.Exec(query,
 			packID, q.QueryName, q.Name, q.Description, q.Interval,
 			q.Snapshot, q.Removed, q.Shard, q.Platform, q.Version,
@@ -113,24 +140,30 @@ func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
 	return nil
 }
 
-func (d *Datastore) GetPackSpecs() (specs []*kolide.PackSpec, err error) {
-	tx, err := d.db.Beginx()
-	if err != nil {
-		return nil, errors.Wrap(err, "begin GetPackSpecs transaction")
-	}
-
-	defer func() {
+func (d *Datastore) GetPackSpecs(opts ...kolide.OptionalArg) (specs []*kolide.PackSpec, err error) {
+	tx, usingCallerTx := d.getTransaction(opts).(*sqlx.Tx)
+	if !usingCallerTx {
+		// No caller-supplied transaction: open one so the three
+		// queries below (packs, targets, queries) see a consistent
+		// snapshot of the database.
+		tx, err = d.db.Beginx()
 		if err != nil {
-			rbErr := tx.Rollback()
-			// It seems possible that there might be a case in
-			// which the error we are dealing with here was thrown
-			// by the call to tx.Commit(), and the docs suggest
-			// this call would then result in sql.ErrTxDone.
-			if rbErr != nil && rbErr != sql.ErrTxDone {
-				panic(fmt.Sprintf("got err '%s' rolling back after err '%s'", rbErr, err))
-			}
+			return nil, errors.Wrap(err, "begin GetPackSpecs transaction")
 		}
-	}()
+
+		defer func() {
+			if err != nil {
+				rbErr := tx.Rollback()
+				// It seems possible that there might be a case in
+				// which the error we are dealing with here was thrown
+				// by the call to tx.Commit(), and the docs suggest
+				// this call would then result in sql.ErrTxDone.
+				if rbErr != nil && rbErr != sql.ErrTxDone {
+					panic(fmt.Sprintf("got err '%s' rolling back after err '%s'", rbErr, err))
+				}
+			}
+		}()
+	}
 
 	// Get basic specs
 	query := "SELECT id, name, description, platform FROM packs"
@@ -152,21 +185,22 @@ WHERE pack_id = ? AND pt.type = ? AND pt.target_id = l.id
 
 	// Load queries
 	for _, spec := range specs {
-		query = `
+		query = fmt.Sprintf(`
 SELECT
-query_name, name, description, ` + "`interval`" + `,
+query_name, name, description, %s,
 snapshot, removed, shard, platform, version
 FROM scheduled_queries
 WHERE pack_id = ?
-`
+`, dialect.Quote("interval"))
 		if err := tx.Select(&spec.Queries, query, spec.ID); err != nil {
 			return nil, errors.Wrap(err, "get pack queries")
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, errors.Wrap(err, "commit transaction")
+	if !usingCallerTx {
+		if err = tx.Commit(); err != nil {
+			return nil, errors.Wrap(err, "commit transaction")
+		}
 	}
 
 	return specs, nil
@@ -197,10 +231,11 @@ func (d *Datastore) DeletePack(pid uint) error {
 }
 
 // Pack fetch kolide.Pack with matching ID
-func (d *Datastore) Pack(pid uint) (*kolide.Pack, error) {
+func (d *Datastore) Pack(pid uint, opts ...kolide.OptionalArg) (*kolide.Pack, error) {
+	db := d.getTransaction(opts)
 	query := `SELECT * FROM packs WHERE id = ? AND NOT deleted`
 	pack := &kolide.Pack{}
-	err := d.db.Get(pack, query, pid)
+	err := db.Get(pack, query, pid)
 	if err == sql.ErrNoRows {
 		return nil, notFound("Pack").WithID(pid)
 	} else if err != nil {
@@ -211,10 +246,11 @@ func (d *Datastore) Pack(pid uint) (*kolide.Pack, error) {
 }
 
 // ListPacks returns all kolide.Pack records limited and sorted by kolide.ListOptions
-func (d *Datastore) ListPacks(opt kolide.ListOptions) ([]*kolide.Pack, error) {
-	query := `SELECT * FROM packs WHERE NOT deleted`
+func (d *Datastore) ListPacks(opt kolide.ListOptions, opts ...kolide.OptionalArg) ([]*kolide.Pack, error) {
+	db := d.getTransaction(opts)
+	query := dialect.Paginate(`SELECT * FROM packs WHERE NOT deleted`, opt.PerPage, opt.Page*opt.PerPage)
 	packs := []*kolide.Pack{}
-	err := d.db.Select(&packs, appendListOptionsToSQL(query, opt))
+	err := db.Select(&packs, query)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "listing packs")
 	}
@@ -222,7 +258,8 @@ func (d *Datastore) ListPacks(opt kolide.ListOptions) ([]*kolide.Pack, error) {
 }
 
 // ListLabelsForPack will return a list of kolide.Label records associated with kolide.Pack
-func (d *Datastore) ListLabelsForPack(pid uint) ([]*kolide.Label, error) {
+func (d *Datastore) ListLabelsForPack(pid uint, opts ...kolide.OptionalArg) ([]*kolide.Label, error) {
+	db := d.getTransaction(opts)
 	query := `
 	SELECT
 		l.id,
@@ -244,14 +281,15 @@ func (d *Datastore) ListLabelsForPack(pid uint) ([]*kolide.Label, error) {
 
 	labels := []*kolide.Label{}
 
-	if err := d.db.Select(&labels, query, kolide.TargetLabel, pid); err != nil && err != sql.ErrNoRows {
+	if err := db.Select(&labels, query, kolide.TargetLabel, pid); err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "listing labels for pack")
 	}
 
 	return labels, nil
 }
 
-func (d *Datastore) ListPacksForHost(hid uint) ([]*kolide.Pack, error) {
+func (d *Datastore) ListPacksForHost(hid uint, opts ...kolide.OptionalArg) ([]*kolide.Pack, error) {
+	db := d.getTransaction(opts)
 	query := `
 		SELECT DISTINCT p.*
 		FROM packs p
@@ -267,14 +305,15 @@ func (d *Datastore) ListPacksForHost(hid uint) ([]*kolide.Pack, error) {
 	`
 
 	packs := []*kolide.Pack{}
-	if err := d.db.Select(&packs, query, kolide.TargetLabel, hid); err != nil && err != sql.ErrNoRows {
+	if err := db.Select(&packs, query, kolide.TargetLabel, hid); err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "listing hosts in pack")
 	}
 	return packs, nil
 }
 
-func (d *Datastore) ListHostsInPack(pid uint, opt kolide.ListOptions) ([]uint, error) {
-	query := `
+func (d *Datastore) ListHostsInPack(pid uint, opt kolide.ListOptions, opts ...kolide.OptionalArg) ([]uint, error) {
+	db := d.getTransaction(opts)
+	query := dialect.Paginate(`
 		SELECT DISTINCT h.id
 		FROM hosts h
 		JOIN pack_targets pt
@@ -289,10 +328,10 @@ func (d *Datastore) ListHostsInPack(pid uint, opt kolide.ListOptions) ([]uint, e
 		  AND pt.type = ?
 		)
 		WHERE pt.pack_id = ?
-	`
+	`, opt.PerPage, opt.Page*opt.PerPage)
 
 	hosts := []uint{}
-	if err := d.db.Select(&hosts, appendListOptionsToSQL(query, opt), kolide.TargetLabel, kolide.TargetHost, pid); err != nil && err != sql.ErrNoRows {
+	if err := db.Select(&hosts, query, kolide.TargetLabel, kolide.TargetHost, pid); err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "listing hosts in pack")
 	}
 	return hosts, nil
@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPacksPaginatesThroughDialect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ds := &Datastore{db: sqlx.NewDb(db, "mysql")}
+
+	mock.ExpectQuery(`SELECT \* FROM packs WHERE NOT deleted LIMIT 5 OFFSET 10`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = ds.ListPacks(kolide.ListOptions{Page: 2, PerPage: 5})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListPacksNoPaginationWhenPerPageZero(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ds := &Datastore{db: sqlx.NewDb(db, "mysql")}
+
+	mock.ExpectQuery(`^SELECT \* FROM packs WHERE NOT deleted$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = ds.ListPacks(kolide.ListOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListHostsInPackPaginatesThroughDialect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ds := &Datastore{db: sqlx.NewDb(db, "mysql")}
+
+	mock.ExpectQuery(`(?s)WHERE pt.pack_id = \?\s*LIMIT 3 OFFSET 0`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = ds.ListHostsInPack(1, kolide.ListOptions{Page: 0, PerPage: 3})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyPackSpecMergeModeUpsertsScheduledQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "mysql")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO packs`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT id FROM packs WHERE name = \?`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`INSERT INTO scheduled_queries.*ON DUPLICATE KEY UPDATE`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM pack_targets`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, err := sqlxDB.Beginx()
+	require.NoError(t, err)
+
+	spec := &kolide.PackSpec{
+		Name:    "pack1",
+		Queries: []kolide.ScheduledQuery{{QueryName: "q1", Interval: 60}},
+	}
+
+	err = applyPackSpec(tx, spec, kolide.ApplyOptions{Mode: kolide.ApplyModeMerge})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyPackSpecReplaceModeDeletesBeforeInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "mysql")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO packs`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT id FROM packs WHERE name = \?`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`DELETE FROM scheduled_queries WHERE pack_id = \?`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Replace mode's INSERT has no ON DUPLICATE KEY UPDATE clause.
+	mock.ExpectExec(`^\s*INSERT INTO scheduled_queries[^;]*VALUES \([^)]*\)\s*$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM pack_targets`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, err := sqlxDB.Beginx()
+	require.NoError(t, err)
+
+	spec := &kolide.PackSpec{
+		Name:    "pack1",
+		Queries: []kolide.ScheduledQuery{{QueryName: "q1", Interval: 60}},
+	}
+
+	err = applyPackSpec(tx, spec, kolide.ApplyOptions{Mode: kolide.ApplyModeReplace})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
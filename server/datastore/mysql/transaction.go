@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// mysqlErrCode extracts the numeric MySQL error code from err, or 0
+// if err did not originate from the MySQL driver.
+func mysqlErrCode(err error) uint16 {
+	if mysqlErr, ok := errors.Cause(err).(*gomysql.MySQLError); ok {
+		return mysqlErr.Number
+	}
+	return 0
+}
+
+// isDeadlock reports whether err is MySQL error 1213 ("Deadlock
+// found when trying to get lock; try restarting transaction") or
+// 1205 (lock wait timeout exceeded), both of which are safe to retry
+// from the start of the transaction.
+func isDeadlock(err error) bool {
+	switch mysqlErrCode(err) {
+	case 1213, 1205:
+		return true
+	}
+	return false
+}
+
+// retryingTxMaxAttempts bounds how many times in total WithRetryingTx
+// will run fn (the first attempt plus any restarts after a deadlock)
+// before giving up and returning the last error to the caller.
+const retryingTxMaxAttempts = 3
+
+// WithRetryingTx runs fn inside a transaction, following the "always
+// use transactions when querying the database" pattern: fn is
+// re-run from the beginning, up to retryingTxMaxAttempts runs in all,
+// if MySQL reports a deadlock or lock wait timeout, since those are
+// expected under concurrent writers rather than indicative of a bug.
+// fn must be idempotent, since it may execute more than once before
+// it fully commits.
+func (d *Datastore) WithRetryingTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < retryingTxMaxAttempts; attempt++ {
+		err = d.withTx(ctx, fn)
+		if err == nil || !isDeadlock(err) {
+			return err
+		}
+	}
+	return errors.Wrap(err, "exhausted retries on deadlock")
+}
+
+func (d *Datastore) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			rbErr := tx.Rollback()
+			// It seems possible that there might be a case in
+			// which the error we are dealing with here was thrown
+			// by the call to tx.Commit(), and the docs suggest
+			// this call would then result in sql.ErrTxDone.
+			if rbErr != nil && rbErr != sql.ErrTxDone {
+				panic(fmt.Sprintf("got err '%s' rolling back after err '%s'", rbErr, err))
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "commit transaction")
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDeadlock(t *testing.T) {
+	assert.True(t, isDeadlock(&gomysql.MySQLError{Number: 1213}))
+	assert.True(t, isDeadlock(&gomysql.MySQLError{Number: 1205}))
+	assert.False(t, isDeadlock(&gomysql.MySQLError{Number: 1062}))
+	assert.False(t, isDeadlock(nil))
+	assert.False(t, isDeadlock(errors.New("not a mysql error")))
+	// isDeadlock should see through errors.Wrap.
+	assert.True(t, isDeadlock(errors.Wrap(&gomysql.MySQLError{Number: 1213}, "insert/update pack")))
+}
+
+func TestWithRetryingTxRetriesOnDeadlockThenSucceeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ds := &Datastore{db: sqlx.NewDb(db, "mysql")}
+
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(&gomysql.MySQLError{Number: 1213, Message: "deadlock found"})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	attempts := 0
+	err = ds.WithRetryingTx(context.Background(), func(tx *sqlx.Tx) error {
+		attempts++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithRetryingTxGivesUpOnNonDeadlockError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ds := &Datastore{db: sqlx.NewDb(db, "mysql")}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	attempts := 0
+	err = ds.WithRetryingTx(context.Background(), func(tx *sqlx.Tx) error {
+		attempts++
+		return errors.New("not a deadlock")
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
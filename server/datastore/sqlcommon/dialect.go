@@ -0,0 +1,46 @@
+// Package sqlcommon holds SQL syntax that differs between the
+// datastore backends Fleet supports (MySQL, PostgreSQL) behind a
+// small Dialect interface. Query-building code in
+// server/datastore/mysql is ported to it incrementally, one query at
+// a time, starting with ApplyPackSpecs/GetPackSpecs.
+package sqlcommon
+
+import "fmt"
+
+// Dialect covers the handful of places MySQL and PostgreSQL SQL
+// actually diverge structurally: identifier quoting, upsert syntax,
+// and pagination. Placeholder syntax also differs but is not covered
+// here (see Postgres); most other DML/DDL is written the same way
+// regardless of backend and doesn't belong here.
+type Dialect interface {
+	// Name is the driver name that New selects this dialect by.
+	Name() string
+
+	// Quote returns identifier wrapped in this dialect's identifier
+	// quoting (backticks for MySQL, double quotes for Postgres).
+	Quote(identifier string) string
+
+	// Upsert returns the clause appended after an INSERT's VALUES
+	// list to update updateCols on conflict. conflictCols names the
+	// columns the conflict is detected on; MySQL ignores them
+	// (conflict is implicit in the table's keys) but Postgres'
+	// ON CONFLICT requires them.
+	Upsert(conflictCols, updateCols []string) string
+
+	// Paginate appends a LIMIT/OFFSET clause to query. A limit of 0
+	// means no limit.
+	Paginate(query string, limit, offset uint) string
+}
+
+// New returns the Dialect registered for driver, which must be "" or
+// "mysql" (the default) or "postgres".
+func New(driver string) (Dialect, error) {
+	switch driver {
+	case "", "mysql":
+		return MySQL{}, nil
+	case "postgres":
+		return Postgres{}, nil
+	default:
+		return nil, fmt.Errorf("unknown datastore driver %q", driver)
+	}
+}
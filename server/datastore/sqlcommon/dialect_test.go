@@ -0,0 +1,72 @@
+package sqlcommon
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		driver   string
+		wantName string
+		wantErr  bool
+	}{
+		{"", "mysql", false},
+		{"mysql", "mysql", false},
+		{"postgres", "postgres", false},
+		{"sqlite", "", true},
+	}
+
+	for _, c := range cases {
+		got, err := New(c.driver)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("New(%q): expected error, got nil", c.driver)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%q): unexpected error: %v", c.driver, err)
+			continue
+		}
+		if got.Name() != c.wantName {
+			t.Errorf("New(%q): got %q, want %q", c.driver, got.Name(), c.wantName)
+		}
+	}
+}
+
+func TestMySQLQuote(t *testing.T) {
+	if got, want := MySQL{}.Quote("interval"), "`interval`"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPostgresQuote(t *testing.T) {
+	if got, want := Postgres{}.Quote("interval"), `"interval"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMySQLUpsert(t *testing.T) {
+	got := MySQL{}.Upsert([]string{"name"}, []string{"name", "description"})
+	want := "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `description` = VALUES(`description`)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPostgresUpsert(t *testing.T) {
+	got := Postgres{}.Upsert([]string{"name"}, []string{"name", "description"})
+	want := `ON CONFLICT ("name") DO UPDATE SET "name" = EXCLUDED."name", "description" = EXCLUDED."description"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	for _, d := range []Dialect{MySQL{}, Postgres{}} {
+		if got, want := d.Paginate("SELECT 1", 0, 0), "SELECT 1"; got != want {
+			t.Errorf("%s: got %q, want %q", d.Name(), got, want)
+		}
+		if got, want := d.Paginate("SELECT 1", 10, 5), "SELECT 1 LIMIT 10 OFFSET 5"; got != want {
+			t.Errorf("%s: got %q, want %q", d.Name(), got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package sqlcommon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MySQL is the Dialect for MySQL/MariaDB, Fleet's original and still
+// default datastore backend.
+type MySQL struct{}
+
+func (MySQL) Name() string { return "mysql" }
+
+func (m MySQL) Quote(identifier string) string {
+	return "`" + identifier + "`"
+}
+
+func (m MySQL) Upsert(conflictCols, updateCols []string) string {
+	clauses := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		q := m.Quote(col)
+		clauses[i] = fmt.Sprintf("%s = VALUES(%s)", q, q)
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(clauses, ", ")
+}
+
+func (m MySQL) Paginate(query string, limit, offset uint) string {
+	if limit == 0 {
+		return query
+	}
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
@@ -0,0 +1,41 @@
+package sqlcommon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Postgres is the Dialect for PostgreSQL, added so operators who
+// already run Postgres elsewhere don't need to stand up a separate
+// MySQL cluster just for Fleet. Note that Dialect only covers syntax
+// that differs structurally between backends; the `?` -> `$N`
+// placeholder rewrite is a purely positional transform and is left to
+// sqlx.Rebind at the call site.
+type Postgres struct{}
+
+func (Postgres) Name() string { return "postgres" }
+
+func (p Postgres) Quote(identifier string) string {
+	return `"` + identifier + `"`
+}
+
+func (p Postgres) Upsert(conflictCols, updateCols []string) string {
+	clauses := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		q := p.Quote(col)
+		clauses[i] = fmt.Sprintf("%s = EXCLUDED.%s", q, q)
+	}
+	quotedConflict := make([]string, len(conflictCols))
+	for i, col := range conflictCols {
+		quotedConflict[i] = p.Quote(col)
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(quotedConflict, ", "), strings.Join(clauses, ", "))
+}
+
+func (p Postgres) Paginate(query string, limit, offset uint) string {
+	if limit == 0 {
+		return query
+	}
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
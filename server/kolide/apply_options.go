@@ -0,0 +1,30 @@
+package kolide
+
+// ApplyMode controls how ApplyPackSpecs reconciles an incoming batch
+// of pack specs against what is already stored.
+type ApplyMode int
+
+const (
+	// ApplyModeReplace deletes scheduled queries and targets that
+	// aren't mentioned in the incoming spec before re-inserting the
+	// ones that are. This is the historical, and default, behavior.
+	ApplyModeReplace ApplyMode = iota
+
+	// ApplyModeMerge preserves scheduled queries whose query_name
+	// wasn't mentioned in the incoming spec, so applying a partial
+	// pack.yml doesn't orphan schedules another file is responsible
+	// for.
+	ApplyModeMerge
+)
+
+// ApplyOptions configures ApplyPackSpecs.
+type ApplyOptions struct {
+	Mode ApplyMode
+
+	// Strict rejects the whole batch if any scheduled query fails
+	// validation (see mysql.validatePackSpecs). When false, each
+	// invalid value is clamped to a safe one (or dropped, if it can't
+	// be) and the query is applied with a warning instead of failing
+	// outright.
+	Strict bool
+}
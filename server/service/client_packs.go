@@ -0,0 +1,77 @@
+package service
+
+import (
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+type specDiffRequest struct {
+	Specs []*kolide.PackSpec `json:"specs"`
+}
+
+type specDiffResponse struct {
+	Diff *SpecDiff `json:"diff,omitempty"`
+	Err  string    `json:"error,omitempty"`
+}
+
+func (r specDiffResponse) error() string { return r.Err }
+
+type getPackSpecsResponse struct {
+	Specs []*kolide.PackSpec `json:"specs"`
+	Err   string             `json:"error,omitempty"`
+}
+
+func (r getPackSpecsResponse) error() string { return r.Err }
+
+// DiffPacks asks the server to compute the diff between specs and
+// what's currently stored, without applying anything. It backs
+// `fleetctl apply --dry-run` and the summary fleetctl prints after a
+// real apply.
+func (c *Client) DiffPacks(specs []*kolide.PackSpec) (*SpecDiff, error) {
+	request := specDiffRequest{Specs: specs}
+	response, err := c.AuthenticatedDo("POST", "/api/v1/kolide/spec/packs:diff", "", request)
+	if err != nil {
+		return nil, errors.Wrap(err, "POST /api/v1/kolide/spec/packs:diff")
+	}
+	defer response.Body.Close()
+
+	var responseBody specDiffResponse
+	if err := c.parseResponse(response, &responseBody); err != nil {
+		return nil, err
+	}
+
+	return responseBody.Diff, nil
+}
+
+// GetPacks fetches every pack spec currently stored on the server.
+// It backs `fleetctl get packs` and the diff fleetctl computes
+// against what the server already has.
+func (c *Client) GetPacks() ([]*kolide.PackSpec, error) {
+	response, err := c.AuthenticatedDo("GET", "/api/v1/kolide/spec/packs", "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /api/v1/kolide/spec/packs")
+	}
+	defer response.Body.Close()
+
+	var responseBody getPackSpecsResponse
+	if err := c.parseResponse(response, &responseBody); err != nil {
+		return nil, err
+	}
+
+	return responseBody.Specs, nil
+}
+
+// ApplyPacksWithOptions applies specs with opts, which is what lets
+// `fleetctl apply --merge` and `--strict` reach ds.ApplyPackSpecs
+// instead of it always running with the zero value kolide.ApplyOptions{}.
+func (c *Client) ApplyPacksWithOptions(specs []*kolide.PackSpec, opts kolide.ApplyOptions) error {
+	request := applyPackSpecsRequest{Specs: specs, Options: opts}
+	response, err := c.AuthenticatedDo("POST", "/api/v1/kolide/spec/packs", "", request)
+	if err != nil {
+		return errors.Wrap(err, "POST /api/v1/kolide/spec/packs")
+	}
+	defer response.Body.Close()
+
+	var responseBody applyPackSpecsResponse
+	return c.parseResponse(response, &responseBody)
+}
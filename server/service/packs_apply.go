@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+type applyPackSpecsRequest struct {
+	Specs   []*kolide.PackSpec  `json:"specs"`
+	Options kolide.ApplyOptions `json:"options"`
+}
+
+type applyPackSpecsResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r applyPackSpecsResponse) error() string { return r.Err }
+
+// ApplyPackSpecsHandler serves POST /api/v1/kolide/spec/packs: it
+// applies the incoming batch of pack specs with req.Options, which is
+// how --merge and --strict (see cmd/fleetctl/apply.go) reach
+// ds.ApplyPackSpecs instead of it always being called with the zero
+// value kolide.ApplyOptions{}.
+func ApplyPackSpecsHandler(ds kolide.Datastore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req applyPackSpecsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := ds.ApplyPackSpecs(req.Specs, req.Options); err != nil {
+			writeJSONError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(applyPackSpecsResponse{})
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+// QueryDiff describes how a single scheduled query within a pack
+// changed between an apply's incoming spec and what's currently
+// stored.
+type QueryDiff struct {
+	QueryName string `json:"query_name"`
+	Change    string `json:"change"` // "added", "removed", or "modified"
+}
+
+// TargetDiff describes a label target a pack gained or lost.
+type TargetDiff struct {
+	Label  string `json:"label"`
+	Change string `json:"change"` // "added" or "removed"
+}
+
+// PackDiff is the computed delta for a single pack between its
+// current stored spec and an incoming one. New is true when the
+// incoming spec names a pack that doesn't exist yet, in which case
+// Queries/Targets are left empty since everything in the incoming
+// spec counts as "added".
+type PackDiff struct {
+	PackName string       `json:"pack_name"`
+	New      bool         `json:"new"`
+	Queries  []QueryDiff  `json:"queries,omitempty"`
+	Targets  []TargetDiff `json:"targets,omitempty"`
+}
+
+// SpecDiff is the result returned by the packs:diff endpoint: one
+// PackDiff per pack named in the incoming batch, in the order it was
+// supplied.
+type SpecDiff struct {
+	Packs []PackDiff `json:"packs"`
+}
+
+// diffPackSpecs compares incoming against current (the specs as
+// currently stored, i.e. GetPackSpecs' output) and returns the
+// structured delta fleetctl prints for both `apply --dry-run` and
+// the post-apply summary.
+func diffPackSpecs(current, incoming []*kolide.PackSpec) *SpecDiff {
+	currentByName := make(map[string]*kolide.PackSpec, len(current))
+	for _, spec := range current {
+		currentByName[spec.Name] = spec
+	}
+
+	diff := &SpecDiff{}
+	for _, spec := range incoming {
+		existing, ok := currentByName[spec.Name]
+		if !ok {
+			diff.Packs = append(diff.Packs, PackDiff{PackName: spec.Name, New: true})
+			continue
+		}
+		diff.Packs = append(diff.Packs, PackDiff{
+			PackName: spec.Name,
+			Queries:  diffQueries(existing.Queries, spec.Queries),
+			Targets:  diffTargets(existing.Targets.Labels, spec.Targets.Labels),
+		})
+	}
+	return diff
+}
+
+func diffQueries(current, incoming []kolide.ScheduledQuery) []QueryDiff {
+	currentByName := make(map[string]kolide.ScheduledQuery, len(current))
+	for _, q := range current {
+		currentByName[q.QueryName] = q
+	}
+
+	seen := make(map[string]bool, len(incoming))
+	var diffs []QueryDiff
+	for _, q := range incoming {
+		seen[q.QueryName] = true
+		existing, ok := currentByName[q.QueryName]
+		switch {
+		case !ok:
+			diffs = append(diffs, QueryDiff{QueryName: q.QueryName, Change: "added"})
+		case !reflect.DeepEqual(existing, q):
+			diffs = append(diffs, QueryDiff{QueryName: q.QueryName, Change: "modified"})
+		}
+	}
+	for _, q := range current {
+		if !seen[q.QueryName] {
+			diffs = append(diffs, QueryDiff{QueryName: q.QueryName, Change: "removed"})
+		}
+	}
+	return diffs
+}
+
+func diffTargets(current, incoming []string) []TargetDiff {
+	currentSet := make(map[string]bool, len(current))
+	for _, l := range current {
+		currentSet[l] = true
+	}
+	incomingSet := make(map[string]bool, len(incoming))
+
+	var diffs []TargetDiff
+	for _, l := range incoming {
+		incomingSet[l] = true
+		if !currentSet[l] {
+			diffs = append(diffs, TargetDiff{Label: l, Change: "added"})
+		}
+	}
+	for _, l := range current {
+		if !incomingSet[l] {
+			diffs = append(diffs, TargetDiff{Label: l, Change: "removed"})
+		}
+	}
+	return diffs
+}
+
+// DiffPackSpecsHandler serves POST /api/v1/kolide/spec/packs:diff: it
+// reads an incoming batch of pack specs, loads what's currently
+// stored in ds, and responds with the diffPackSpecs delta between
+// them. This is what Client.DiffPacks calls, so `fleetctl apply
+// --dry-run` and the post-apply summary get a real diff instead of
+// 404ing against a server that never served the route.
+func DiffPackSpecsHandler(ds kolide.Datastore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req specDiffRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		current, err := ds.GetPackSpecs()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(specDiffResponse{Diff: diffPackSpecs(current, req.Specs)})
+	})
+}
+
+// writeJSONError writes a {"error": "..."} body with status. Every
+// response type in this package (specDiffResponse,
+// getPackSpecsResponse, applyPackSpecsResponse, ...) embeds an Err
+// field with that same json tag, so this shape round-trips through
+// all of them regardless of which endpoint hit the error.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Err string `json:"error"`
+	}{Err: err.Error()})
+}
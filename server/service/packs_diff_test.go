@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPackSpecsNewPack(t *testing.T) {
+	incoming := []*kolide.PackSpec{{Name: "new-pack"}}
+
+	diff := diffPackSpecs(nil, incoming)
+
+	if assert.Len(t, diff.Packs, 1) {
+		assert.Equal(t, "new-pack", diff.Packs[0].PackName)
+		assert.True(t, diff.Packs[0].New)
+		assert.Empty(t, diff.Packs[0].Queries)
+	}
+}
+
+func TestDiffPackSpecsExistingPack(t *testing.T) {
+	current := []*kolide.PackSpec{
+		{
+			Name: "pack1",
+			Queries: []kolide.ScheduledQuery{
+				{QueryName: "unchanged", Interval: 60},
+				{QueryName: "modified", Interval: 60},
+				{QueryName: "removed", Interval: 60},
+			},
+		},
+	}
+	incoming := []*kolide.PackSpec{
+		{
+			Name: "pack1",
+			Queries: []kolide.ScheduledQuery{
+				{QueryName: "unchanged", Interval: 60},
+				{QueryName: "modified", Interval: 120},
+				{QueryName: "added", Interval: 60},
+			},
+		},
+	}
+
+	diff := diffPackSpecs(current, incoming)
+
+	if assert.Len(t, diff.Packs, 1) {
+		pack := diff.Packs[0]
+		assert.False(t, pack.New)
+
+		changes := map[string]string{}
+		for _, q := range pack.Queries {
+			changes[q.QueryName] = q.Change
+		}
+		assert.Equal(t, map[string]string{
+			"modified": "modified",
+			"removed":  "removed",
+			"added":    "added",
+		}, changes)
+	}
+}
+
+func TestDiffQueries(t *testing.T) {
+	current := []kolide.ScheduledQuery{
+		{QueryName: "a", Interval: 10},
+		{QueryName: "b", Interval: 20},
+	}
+	incoming := []kolide.ScheduledQuery{
+		{QueryName: "a", Interval: 99},
+		{QueryName: "c", Interval: 5},
+	}
+
+	diffs := diffQueries(current, incoming)
+
+	changes := map[string]string{}
+	for _, d := range diffs {
+		changes[d.QueryName] = d.Change
+	}
+	assert.Equal(t, map[string]string{
+		"a": "modified",
+		"b": "removed",
+		"c": "added",
+	}, changes)
+}
+
+func TestDiffTargets(t *testing.T) {
+	diffs := diffTargets([]string{"kept", "dropped"}, []string{"kept", "gained"})
+
+	changes := map[string]string{}
+	for _, d := range diffs {
+		changes[d.Label] = d.Change
+	}
+	assert.Equal(t, map[string]string{
+		"dropped": "removed",
+		"gained":  "added",
+	}, changes)
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+// GetPackSpecsHandler serves GET /api/v1/kolide/spec/packs: it
+// returns every pack spec currently stored, which is what
+// Client.GetPacks calls to back `fleetctl get packs`.
+func GetPackSpecsHandler(ds kolide.Datastore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		specs, err := ds.GetPackSpecs()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(getPackSpecsResponse{Specs: specs})
+	})
+}